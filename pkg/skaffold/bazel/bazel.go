@@ -0,0 +1,87 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bazel wraps the `bazel` CLI so the higher-level build/bazel
+// builder doesn't shell out directly.
+package bazel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/pkg/errors"
+)
+
+// GetDependencies returns the workspace-relative source files a bazel target
+// depends on, by querying bazel locally.
+func GetDependencies(ctx context.Context, workspace string, a *latest.BazelArtifact) ([]string, error) {
+	query := fmt.Sprintf(`kind("source file", deps(%s))`, a.BuildTarget)
+	return runQuery(ctx, workspace, query)
+}
+
+// Query returns the same dependency set as GetDependencies, but is used when
+// the build will run against Remote Build Execution: the remote executor
+// already has these paths cached, so callers shouldn't read them off the
+// local filesystem.
+func Query(ctx context.Context, workspace string, a *latest.BazelArtifact) ([]string, error) {
+	query := fmt.Sprintf(`kind("source file", deps(%s))`, a.BuildTarget)
+	return runQuery(ctx, workspace, query)
+}
+
+func runQuery(ctx context.Context, workspace, query string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "bazel", "query", query, "--output", "label")
+	cmd.Dir = workspace
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "running bazel query: %s", stderr.String())
+	}
+
+	var paths []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		// Labels from external repositories (@repo//...) aren't workspace files.
+		if line == "" || strings.HasPrefix(line, "@") {
+			continue
+		}
+		// "//pkg/sub:file.go" -> "pkg/sub/file.go", "//:file.go" -> "file.go"
+		paths = append(paths, strings.TrimPrefix(strings.Replace(strings.TrimPrefix(line, "//"), ":", "/", 1), "/"))
+	}
+	return paths, nil
+}
+
+// Build runs `bazel build` for the given target, streaming its stdout and
+// stderr to out, with any extra flags (e.g. Remote Build Execution flags or
+// --build_event_json_file) appended.
+func Build(ctx context.Context, out io.Writer, workspace string, a *latest.BazelArtifact, extraArgs []string) error {
+	args := append([]string{"build", a.BuildTarget}, a.BuildArgs...)
+	args = append(args, extraArgs...)
+
+	cmd := exec.CommandContext(ctx, "bazel", args...)
+	cmd.Dir = workspace
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, "running bazel build")
+	}
+	return nil
+}
@@ -19,9 +19,11 @@ package bazel
 import (
 	"context"
 	"io"
+	"sync"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/bazel"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/local"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
@@ -44,6 +46,11 @@ func NewBuilder() *Builder {
 	return &Builder{}
 }
 
+// Name is how users address this builder from build.artifacts[].plugin.
+func (b *Builder) Name() string {
+	return "bazel"
+}
+
 // Init stores skaffold options and the execution environment
 func (b *Builder) Init(opts *config.SkaffoldOptions, env *latest.ExecutionEnvironment) {
 	b.opts = opts
@@ -57,7 +64,11 @@ func (b *Builder) Labels() map[string]string {
 	}
 }
 
-// DependenciesForArtifact returns the dependencies for this bazel artifact
+// DependenciesForArtifact returns the dependencies for this bazel artifact.
+// When building against Remote Build Execution, the paths come from
+// bazel.Query, which runs the same `bazel query` as the local path; Build
+// marks such artifacts query-only so the digester doesn't read those files
+// off the local filesystem.
 func (b *Builder) DependenciesForArtifact(ctx context.Context, artifact *latest.Artifact) ([]string, error) {
 	if err := setArtifact(artifact); err != nil {
 		return nil, err
@@ -65,6 +76,15 @@ func (b *Builder) DependenciesForArtifact(ctx context.Context, artifact *latest.
 	if artifact.BazelArtifact == nil {
 		return nil, errors.New("bazel artifact is nil")
 	}
+
+	if b.env != nil && b.env.Name == constants.BazelRemote {
+		paths, err := bazel.Query(ctx, artifact.Workspace, artifact.BazelArtifact)
+		if err != nil {
+			return nil, errors.Wrap(err, "querying bazel dependencies")
+		}
+		return util.AbsolutePaths(artifact.Workspace, paths), nil
+	}
+
 	paths, err := bazel.GetDependencies(ctx, artifact.Workspace, artifact.BazelArtifact)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting bazel dependencies")
@@ -75,15 +95,70 @@ func (b *Builder) DependenciesForArtifact(ctx context.Context, artifact *latest.
 // Build is responsible for building artifacts in their respective execution environments
 // The builder plugin is also responsible for setting any necessary defaults
 func (b *Builder) Build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	digester, err := cache.NewArtifactDigester(b.DependenciesForArtifact)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up artifact digest cache")
+	}
+	digester.QueryOnly = func(context.Context, *latest.Artifact) bool {
+		return b.env != nil && b.env.Name == constants.BazelRemote
+	}
+	unchanged, toBuild, digests, err := digester.Partition(ctx, artifacts, b.opts.ForceRebuild)
+	if err != nil {
+		return nil, errors.Wrap(err, "checking artifact digests")
+	}
+
+	built, err := b.build(ctx, out, tags, toBuild)
+	if err != nil {
+		return nil, err
+	}
+
+	byImageName := make(map[string]build.Artifact, len(unchanged)+len(built))
+	for _, a := range unchanged {
+		byImageName[a.ImageName] = a
+	}
+	for _, a := range built {
+		// Query-only artifacts (Remote Build Execution) never got a digest
+		// from Partition, so there's no InputDigest to persist for them.
+		if digest, ok := digests[a.ImageName]; ok {
+			if err := digester.Update(a.ImageName, digest, a); err != nil {
+				return nil, errors.Wrapf(err, "updating artifact digest cache for %s", a.ImageName)
+			}
+		}
+		byImageName[a.ImageName] = a
+	}
+
+	results := make([]build.Artifact, len(artifacts))
+	for i, a := range artifacts {
+		results[i] = byImageName[a.ImageName]
+	}
+	return results, nil
+}
+
+// build dispatches the artifacts that need rebuilding to the execution
+// environment's inner builder.
+func (b *Builder) build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	if len(artifacts) == 0 {
+		return nil, nil
+	}
 	switch b.env.Name {
 	case constants.Local:
 		return b.local(ctx, out, tags, artifacts)
+	case constants.BazelRemote:
+		return b.remote(ctx, out, tags, artifacts)
+	case constants.GoogleCloudBuild:
+		return b.googleCloudBuild(ctx, out, tags, artifacts)
 	default:
 		return nil, errors.Errorf("%s is not a supported environment for builder bazel", b.env.Name)
 	}
 }
 
-// local sets any necessary defaults and then builds artifacts with bazel locally
+// local sets any necessary defaults and then builds artifacts with bazel
+// locally, assembling each artifact and publishing it through the
+// incremental publisher when a base image is available, falling back to a
+// from-scratch build otherwise. Artifacts are assembled and published
+// concurrently, against one shared local.Builder, so a multi-artifact build
+// gets the same parallelism a single call to local.Builder.Build would have
+// given it.
 func (b *Builder) local(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
 	var l *latest.LocalBuild
 	if err := util.CloneThroughJSON(b.env.Properties, &l); err != nil {
@@ -92,35 +167,87 @@ func (b *Builder) local(ctx context.Context, out io.Writer, tags tag.ImageTags,
 	if l == nil {
 		l = &latest.LocalBuild{}
 	}
+	for _, a := range artifacts {
+		if err := setArtifact(a); err != nil {
+			return nil, errors.Wrapf(err, "setting artifact %s", a.ImageName)
+		}
+	}
+
 	kubeContext, err := kubectx.CurrentContext()
 	if err != nil {
 		return nil, errors.Wrap(err, "getting current cluster context")
 	}
-	builder, err := local.NewBuilder(l, kubeContext, b.opts.SkipTests)
+	localBuilder, err := local.NewBuilder(l, kubeContext, b.opts.SkipTests)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting local builder")
 	}
-	for _, a := range artifacts {
-		if err := setArtifact(a); err != nil {
-			return nil, errors.Wrapf(err, "setting artifact %s", a.ImageName)
+
+	standard := &standardPublisher{localBuilder: localBuilder, out: out}
+	publisher, err := newIncrementalPublisher(standard)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting incremental publisher")
+	}
+
+	assembler := &bazelAssembler{}
+	built := make([]build.Artifact, len(artifacts))
+	errs := make([]error, len(artifacts))
+
+	var wg sync.WaitGroup
+	for i, a := range artifacts {
+		wg.Add(1)
+		go func(i int, a *latest.Artifact) {
+			defer wg.Done()
+			assembled, err := assembler.Assemble(ctx, a)
+			if err != nil {
+				errs[i] = errors.Wrapf(err, "assembling %s", a.ImageName)
+				return
+			}
+			artifact, err := publisher.Publish(ctx, assembled, tags)
+			if err != nil {
+				errs[i] = errors.Wrapf(err, "publishing %s", a.ImageName)
+				return
+			}
+			built[i] = artifact
+		}(i, a)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
 		}
 	}
-	return builder.Build(ctx, out, tags, artifacts)
+	return built, nil
 }
 
-func setArtifact(artifact *latest.Artifact) error {
-	if artifact.ArtifactType.BazelArtifact != nil {
-		return nil
-	}
+// UnmarshalArtifact implements plugin.ArtifactUnmarshaler, so plugin.Registry
+// can validate a bazel BuilderPlugin's raw Contents up front instead of
+// failing deep inside Build.
+func (b *Builder) UnmarshalArtifact(contents []byte) (interface{}, error) {
+	return unmarshalBazelArtifact(contents)
+}
+
+func unmarshalBazelArtifact(contents []byte) (*latest.BazelArtifact, error) {
 	var a *latest.BazelArtifact
-	if err := yaml.UnmarshalStrict(artifact.BuilderPlugin.Contents, &a); err != nil {
-		return errors.Wrap(err, "unmarshalling bazel artifact")
+	if err := yaml.UnmarshalStrict(contents, &a); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling bazel artifact")
 	}
 	if a == nil {
-		return errors.New("artifact is nil")
+		return nil, errors.New("artifact is nil")
 	}
 	if a.BuildTarget == "" {
-		return errors.Errorf("%s must have an associated build target", artifact.ImageName)
+		return nil, errors.New("bazel artifact must have an associated build target")
+	}
+	return a, nil
+}
+
+func setArtifact(artifact *latest.Artifact) error {
+	if artifact.ArtifactType.BazelArtifact != nil {
+		return nil
+	}
+	a, err := unmarshalBazelArtifact(artifact.BuilderPlugin.Contents)
+	if err != nil {
+		return err
 	}
 	artifact.ArtifactType.BazelArtifact = a
 	return nil
@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bazel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/plugin/environments/gcb"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	cloudbuild "google.golang.org/api/cloudbuild/v1"
+)
+
+// googleCloudBuild sets any necessary defaults and then builds artifacts by
+// running bazel inside Google Cloud Build, so a single execution-environment
+// key moves bazel builds off a developer's laptop.
+func (b *Builder) googleCloudBuild(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	var g *latest.GoogleCloudBuild
+	if err := util.CloneThroughJSON(b.env.Properties, &g); err != nil {
+		return nil, errors.Wrap(err, "converting execution env to googleCloudBuild struct")
+	}
+	if g == nil {
+		g = &latest.GoogleCloudBuild{}
+	}
+
+	for _, a := range artifacts {
+		if err := setArtifact(a); err != nil {
+			return nil, errors.Wrapf(err, "setting artifact %s", a.ImageName)
+		}
+	}
+
+	var built []build.Artifact
+	for _, a := range artifacts {
+		artifact, err := b.buildOnGCB(ctx, out, g, tags, a)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s on Google Cloud Build", a.ImageName)
+		}
+		built = append(built, artifact)
+	}
+	return built, nil
+}
+
+// buildOnGCB uploads the artifact's workspace to the configured staging
+// bucket, submits a build that runs gcr.io/cloud-builders/bazel against it,
+// and streams status until the build reaches a terminal state.
+func (b *Builder) buildOnGCB(ctx context.Context, out io.Writer, g *latest.GoogleCloudBuild, tags tag.ImageTags, artifact *latest.Artifact) (build.Artifact, error) {
+	tagged, ok := tags[artifact.ImageName]
+	if !ok {
+		return build.Artifact{}, errors.Errorf("no tag provided for %s", artifact.ImageName)
+	}
+
+	objectName, err := docker.UploadContextToGCS(ctx, artifact.Workspace, g.StagingBucket)
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "uploading workspace to GCS")
+	}
+
+	cb, err := cloudbuild.NewService(ctx)
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "getting cloudbuild client")
+	}
+
+	call := cb.Projects.Builds.Create(g.ProjectID, &cloudbuild.Build{
+		Source: &cloudbuild.Source{
+			StorageSource: &cloudbuild.StorageSource{
+				Bucket: g.StagingBucket,
+				Object: objectName,
+			},
+		},
+		Steps: []*cloudbuild.BuildStep{{
+			Name: "gcr.io/cloud-builders/bazel",
+			Args: []string{"build", artifact.BazelArtifact.BuildTarget},
+		}},
+		Images: []string{tagged},
+	})
+	op, err := call.Do()
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "submitting cloud build")
+	}
+
+	buildID, err := gcb.BuildIDFromOperation(op)
+	if err != nil {
+		return build.Artifact{}, err
+	}
+
+	fmt.Fprintf(out, "Starting build %s...\n", buildID)
+	return build.Artifact{ImageName: artifact.ImageName, Tag: tagged}, b.watchBuild(ctx, out, cb, g.ProjectID, buildID)
+}
+
+// watchBuild polls the cloud build until it reaches a terminal status,
+// streaming progress through out and translating failures into typed errors.
+func (b *Builder) watchBuild(ctx context.Context, out io.Writer, cb *cloudbuild.Service, projectID, buildID string) error {
+	lastStatus := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(gcb.RetryDelay):
+		}
+
+		remoteBuild, err := cb.Projects.Builds.Get(projectID, buildID).Do()
+		if err != nil {
+			return errors.Wrap(err, "getting cloud build status")
+		}
+
+		if remoteBuild.Status != lastStatus {
+			fmt.Fprintf(out, "cloud build status: %s\n", remoteBuild.Status)
+			lastStatus = remoteBuild.Status
+		}
+
+		if err := gcb.ErrorForStatus(remoteBuild.Status); err != nil {
+			return err
+		}
+		if remoteBuild.Status == gcb.StatusSuccess {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bazel
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	skaffoldbazel "github.com/GoogleContainerTools/skaffold/pkg/skaffold/bazel"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/local"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+)
+
+// AssembledFile is a single file produced by an Assembler, identified by its
+// workspace-relative path, content digest and mode. It's the same shape
+// cache.ArtifactDigester hashes a dependency list into, so both caches agree
+// on what "changed" means for a file.
+type AssembledFile = cache.FileHash
+
+// AssembledArtifact is the output of an Assembler: the set of files that make
+// up the artifact, plus a stable digest over those files (cache.DigestFiles).
+// The artifact spec is not part of the digest.
+type AssembledArtifact struct {
+	Artifact *latest.Artifact
+	Files    []AssembledFile
+	Digest   string
+}
+
+// Assembler produces the built artifact's file set without publishing it
+// anywhere.
+type Assembler interface {
+	Assemble(ctx context.Context, artifact *latest.Artifact) (AssembledArtifact, error)
+}
+
+// Publisher turns an AssembledArtifact into a pushable image.
+type Publisher interface {
+	Publish(ctx context.Context, assembled AssembledArtifact, tags tag.ImageTags) (build.Artifact, error)
+}
+
+// bazelAssembler hashes a bazel target's declared source dependencies to
+// produce an AssembledArtifact. It does not run `bazel build`; the files it
+// reports are the target's source files, not its build outputs.
+type bazelAssembler struct{}
+
+func (a *bazelAssembler) Assemble(ctx context.Context, artifact *latest.Artifact) (AssembledArtifact, error) {
+	if err := setArtifact(artifact); err != nil {
+		return AssembledArtifact{}, errors.Wrapf(err, "setting artifact %s", artifact.ImageName)
+	}
+	paths, err := skaffoldbazel.GetDependencies(ctx, artifact.Workspace, artifact.BazelArtifact)
+	if err != nil {
+		return AssembledArtifact{}, errors.Wrap(err, "getting bazel dependencies")
+	}
+
+	files, err := hashPaths(artifact.Workspace, paths)
+	if err != nil {
+		return AssembledArtifact{}, err
+	}
+
+	return AssembledArtifact{
+		Artifact: artifact,
+		Files:    files,
+		Digest:   cache.DigestFiles(files),
+	}, nil
+}
+
+// hashPaths hashes each workspace-relative path with the same cache.HashFile
+// recipe cache.ArtifactDigester uses for its own dependency hashing, so an
+// AssembledArtifact's digest and a digester's InputDigest agree on what
+// "changed" means for a shared file, and sorts the result by path so the
+// digest is stable regardless of the order bazel reported the paths in.
+func hashPaths(workspace string, paths []string) ([]AssembledFile, error) {
+	files := make([]AssembledFile, 0, len(paths))
+	for _, p := range paths {
+		abs := filepath.Join(workspace, p)
+		sum, mode, err := cache.HashFile(abs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "hashing %s", abs)
+		}
+		files = append(files, AssembledFile{Path: p, SHA256: sum, Mode: mode})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// standardPublisher builds and pushes an artifact from scratch, matching the
+// builder's behavior prior to the introduction of the Assembler/Publisher
+// split. localBuilder is shared across every artifact in a build so it's
+// resolved against the cluster's kube-context once, not per artifact.
+type standardPublisher struct {
+	localBuilder *local.Builder
+	out          io.Writer
+}
+
+func (p *standardPublisher) Publish(ctx context.Context, assembled AssembledArtifact, tags tag.ImageTags) (build.Artifact, error) {
+	artifacts, err := p.localBuilder.Build(ctx, p.out, tags, []*latest.Artifact{assembled.Artifact})
+	if err != nil {
+		return build.Artifact{}, err
+	}
+	return artifacts[0], nil
+}
+
+// publishCacheEntry is what's persisted per artifact so a later run can find
+// the base image to build an incremental layer on top of.
+type publishCacheEntry struct {
+	InputDigest string          `json:"inputDigest"`
+	LastRef     string          `json:"lastRef"`
+	Files       []AssembledFile `json:"files"`
+}
+
+type publishCache map[string]publishCacheEntry
+
+// incrementalPublisher reuses the previously published image for an artifact
+// as a base layer, writing only the changed or added files as a new top
+// layer. It falls back to the standardPublisher on the first build for an
+// artifact or when the base image can't be pulled.
+type incrementalPublisher struct {
+	fallback *standardPublisher
+	store    *cache.Store
+	cache    publishCache
+}
+
+func newIncrementalPublisher(fallback *standardPublisher) (*incrementalPublisher, error) {
+	store, err := cache.NewStore("bazel-incremental-publish-cache.json")
+	if err != nil {
+		return nil, err
+	}
+	publishCache := publishCache{}
+	if err := store.Load(&publishCache); err != nil {
+		return nil, errors.Wrap(err, "loading incremental publish cache")
+	}
+	return &incrementalPublisher{fallback: fallback, store: store, cache: publishCache}, nil
+}
+
+func (p *incrementalPublisher) Publish(ctx context.Context, assembled AssembledArtifact, tags tag.ImageTags) (build.Artifact, error) {
+	entry, ok := p.cache[assembled.Artifact.ImageName]
+	if !ok {
+		return p.buildFromScratch(ctx, assembled, tags)
+	}
+
+	d := delta(entry.Files, assembled.Files)
+	if len(d.changed) == 0 && len(d.removed) == 0 && entry.InputDigest == assembled.Digest {
+		return build.Artifact{ImageName: assembled.Artifact.ImageName, Tag: entry.LastRef}, nil
+	}
+
+	baseRef, err := parseRef(entry.LastRef)
+	if err != nil {
+		return p.buildFromScratch(ctx, assembled, tags)
+	}
+	base, err := remote.Image(baseRef)
+	if err != nil {
+		// Base image is unreachable: fall back rather than fail the build.
+		return p.buildFromScratch(ctx, assembled, tags)
+	}
+
+	layer, err := newLayerFromFiles(assembled.Artifact.Workspace, d.changed, d.removed)
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "building incremental layer")
+	}
+	img, err := mutate.AppendLayers(base, layer)
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "appending incremental layer")
+	}
+
+	tagged, ok := tags[assembled.Artifact.ImageName]
+	if !ok {
+		return build.Artifact{}, errors.Errorf("no tag provided for %s", assembled.Artifact.ImageName)
+	}
+	tagRef, err := parseRef(tagged)
+	if err != nil {
+		return build.Artifact{}, errors.Wrapf(err, "parsing tag %s", tagged)
+	}
+	if err := remote.Write(tagRef, img); err != nil {
+		return build.Artifact{}, errors.Wrap(err, "pushing incremental image")
+	}
+
+	artifact := build.Artifact{ImageName: assembled.Artifact.ImageName, Tag: tagged}
+	p.cache[assembled.Artifact.ImageName] = publishCacheEntry{
+		InputDigest: assembled.Digest,
+		LastRef:     tagged,
+		Files:       assembled.Files,
+	}
+	if err := p.store.Save(p.cache); err != nil {
+		return build.Artifact{}, errors.Wrap(err, "saving publish cache")
+	}
+	return artifact, nil
+}
+
+func (p *incrementalPublisher) buildFromScratch(ctx context.Context, assembled AssembledArtifact, tags tag.ImageTags) (build.Artifact, error) {
+	artifact, err := p.fallback.Publish(ctx, assembled, tags)
+	if err != nil {
+		return build.Artifact{}, err
+	}
+	p.cache[assembled.Artifact.ImageName] = publishCacheEntry{
+		InputDigest: assembled.Digest,
+		LastRef:     artifact.Tag,
+		Files:       assembled.Files,
+	}
+	if err := p.store.Save(p.cache); err != nil {
+		return build.Artifact{}, errors.Wrap(err, "saving publish cache")
+	}
+	return artifact, nil
+}
+
+func parseRef(ref string) (name.Reference, error) {
+	return name.ParseReference(ref, name.WeakValidation)
+}
+
+// whiteoutPrefix marks a tar entry as an OCI/AUFS whiteout, telling the
+// image runtime to delete the corresponding path from the layers below.
+const whiteoutPrefix = ".wh."
+
+// newLayerFromFiles tars up the given workspace-relative files, adds a
+// whiteout entry for each removed path, and wraps the result in a single
+// image layer.
+func newLayerFromFiles(workspace string, files []AssembledFile, removed []string) (v1.Layer, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		contents, err := ioutil.ReadFile(filepath.Join(workspace, f.Path))
+		if err != nil {
+			return nil, err
+		}
+		hdr := &tar.Header{
+			Name: f.Path,
+			Mode: int64(f.Mode),
+			Size: int64(len(contents)),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(contents); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range removed {
+		hdr := &tar.Header{
+			Name: filepath.Join(filepath.Dir(path), whiteoutPrefix+filepath.Base(path)),
+			Size: 0,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	b := buf.Bytes()
+	return tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	})
+}
+
+// fileDelta is what changed between two AssembledArtifact file sets.
+type fileDelta struct {
+	changed []AssembledFile
+	removed []string
+}
+
+// delta compares base against next and reports the files that are new or
+// changed, plus the paths present in base but no longer in next.
+func delta(base, next []AssembledFile) fileDelta {
+	baseByPath := make(map[string]AssembledFile, len(base))
+	for _, f := range base {
+		baseByPath[f.Path] = f
+	}
+	nextByPath := make(map[string]struct{}, len(next))
+
+	var d fileDelta
+	for _, f := range next {
+		nextByPath[f.Path] = struct{}{}
+		if old, ok := baseByPath[f.Path]; !ok || old.SHA256 != f.SHA256 {
+			d.changed = append(d.changed, f)
+		}
+	}
+	for _, f := range base {
+		if _, ok := nextByPath[f.Path]; !ok {
+			d.removed = append(d.removed, f.Path)
+		}
+	}
+	return d
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bazel
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
+)
+
+func TestDelta(t *testing.T) {
+	base := []AssembledFile{
+		{Path: "a", SHA256: "sum-a"},
+		{Path: "b", SHA256: "sum-b"},
+		{Path: "c", SHA256: "sum-c"},
+	}
+	next := []AssembledFile{
+		{Path: "a", SHA256: "sum-a"},  // unchanged
+		{Path: "b", SHA256: "sum-b2"}, // changed
+		{Path: "d", SHA256: "sum-d"},  // added
+	}
+
+	d := delta(base, next)
+
+	if len(d.changed) != 2 {
+		t.Fatalf("expected 2 changed files, got %d: %v", len(d.changed), d.changed)
+	}
+	changedPaths := map[string]bool{}
+	for _, f := range d.changed {
+		changedPaths[f.Path] = true
+	}
+	if !changedPaths["b"] || !changedPaths["d"] {
+		t.Errorf("expected b and d to be changed, got %v", d.changed)
+	}
+
+	if len(d.removed) != 1 || d.removed[0] != "c" {
+		t.Errorf("expected c to be removed, got %v", d.removed)
+	}
+}
+
+func TestHashPathsSortsAndUsesSharedHashRecipe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skaffold-bazel-hash-paths-test")
+	if err != nil {
+		t.Fatalf("making temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, contents := range map[string]string{"b.txt": "b", "a.txt": "a"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	files, err := hashPaths(dir, []string{"b.txt", "a.txt"})
+	if err != nil {
+		t.Fatalf("hashPaths: %v", err)
+	}
+
+	if len(files) != 2 || files[0].Path != "a.txt" || files[1].Path != "b.txt" {
+		t.Fatalf("hashPaths() = %v, want files sorted by path", files)
+	}
+
+	wantSum, wantMode, err := cache.HashFile(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatalf("cache.HashFile: %v", err)
+	}
+	if files[0].SHA256 != wantSum || files[0].Mode != wantMode {
+		t.Errorf("hashPaths() used a different hash recipe than cache.HashFile: got (%s, %v), want (%s, %v)", files[0].SHA256, files[0].Mode, wantSum, wantMode)
+	}
+
+	// cache.DigestFiles over hashPaths' result should match what it computes
+	// for the equivalent []cache.FileHash built by hand, since AssembledFile
+	// is just an alias for cache.FileHash.
+	equivalent := []cache.FileHash{
+		{Path: files[0].Path, SHA256: files[0].SHA256, Mode: files[0].Mode},
+		{Path: files[1].Path, SHA256: files[1].SHA256, Mode: files[1].Mode},
+	}
+	if cache.DigestFiles(files) != cache.DigestFiles(equivalent) {
+		t.Errorf("cache.DigestFiles(hashPaths(...)) didn't match cache.DigestFiles on the equivalent []cache.FileHash")
+	}
+}
+
+func TestNewLayerFromFilesWritesWhiteouts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skaffold-bazel-layer-test")
+	if err != nil {
+		t.Fatalf("making temp dir: %v", err)
+	}
+
+	layer, err := newLayerFromFiles(dir, nil, []string{"removed/dir/gone.txt"})
+	if err != nil {
+		t.Fatalf("newLayerFromFiles: %v", err)
+	}
+
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		t.Fatalf("reading layer: %v", err)
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading tar entry: %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+
+	want := "removed/dir/.wh.gone.txt"
+	if len(names) != 1 || names[0] != want {
+		t.Errorf("tar entries = %v, want [%s]", names, want)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bazel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	skaffoldbazel "github.com/GoogleContainerTools/skaffold/pkg/skaffold/bazel"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// bepPollInterval is how often stream polls the BEP file for new lines once
+// it catches up to EOF, mirroring `tail -f`.
+const bepPollInterval = 100 * time.Millisecond
+
+// remote sets any necessary defaults and then builds artifacts with bazel
+// against a Remote Build Execution backend.
+func (b *Builder) remote(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	var r *latest.BazelRemoteBuild
+	if err := util.CloneThroughJSON(b.env.Properties, &r); err != nil {
+		return nil, errors.Wrap(err, "converting execution env to bazelRemoteBuild struct")
+	}
+	if r == nil {
+		return nil, errors.New("bazel remote execution requires a remoteExecutor or remoteCache")
+	}
+
+	for _, a := range artifacts {
+		if err := setArtifact(a); err != nil {
+			return nil, errors.Wrapf(err, "setting artifact %s", a.ImageName)
+		}
+	}
+
+	var built []build.Artifact
+	for _, a := range artifacts {
+		artifact, err := b.buildRemote(ctx, out, r, tags, a)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s", a.ImageName)
+		}
+		built = append(built, artifact)
+	}
+	return built, nil
+}
+
+func (b *Builder) buildRemote(ctx context.Context, out io.Writer, r *latest.BazelRemoteBuild, tags tag.ImageTags, artifact *latest.Artifact) (build.Artifact, error) {
+	bep, err := newBEPStreamer(out, artifact.ImageName)
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "setting up build event protocol streaming")
+	}
+	defer bep.close()
+
+	args := remoteFlags(r, bep.path)
+	if err := skaffoldbazel.Build(ctx, bep.writer(), artifact.Workspace, artifact.BazelArtifact, args); err != nil {
+		return build.Artifact{}, errors.Wrap(err, "running bazel build")
+	}
+
+	tagged, ok := tags[artifact.ImageName]
+	if !ok {
+		return build.Artifact{}, errors.Errorf("no tag provided for %s", artifact.ImageName)
+	}
+	return build.Artifact{ImageName: artifact.ImageName, Tag: tagged}, nil
+}
+
+// remoteFlags translates a BazelRemoteBuild's properties into the bazel
+// flags that route the build through Remote Build Execution.
+func remoteFlags(r *latest.BazelRemoteBuild, bepFile string) []string {
+	var args []string
+	if r.RemoteExecutor != "" {
+		args = append(args, fmt.Sprintf("--remote_executor=%s", r.RemoteExecutor))
+	}
+	if r.RemoteCache != "" {
+		args = append(args, fmt.Sprintf("--remote_cache=%s", r.RemoteCache))
+	}
+	if r.InstanceName != "" {
+		args = append(args, fmt.Sprintf("--remote_instance_name=%s", r.InstanceName))
+	}
+	if len(r.ExecProperties) > 0 {
+		args = append(args, fmt.Sprintf("--remote_default_exec_properties=%s", util.EncodeExecProperties(r.ExecProperties)))
+	}
+	if r.DiskCache != "" {
+		args = append(args, fmt.Sprintf("--disk_cache=%s", r.DiskCache))
+	}
+	if r.RepositoryCache != "" {
+		args = append(args, fmt.Sprintf("--repository_cache=%s", r.RepositoryCache))
+	}
+	args = append(args, fmt.Sprintf("--build_event_json_file=%s", bepFile))
+	return args
+}
+
+// bepStreamer tails bazel's build event protocol JSON file and forwards
+// per-artifact progress lines to out as they're written.
+type bepStreamer struct {
+	out       io.Writer
+	imageName string
+	path      string
+	file      *os.File
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func newBEPStreamer(out io.Writer, imageName string) (*bepStreamer, error) {
+	f, err := ioutil.TempFile("", "skaffold-bazel-bep-*.json")
+	if err != nil {
+		return nil, err
+	}
+	s := &bepStreamer{
+		out:       out,
+		imageName: imageName,
+		path:      f.Name(),
+		file:      f,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.stream()
+	return s, nil
+}
+
+// writer returns an io.Writer for bazel's own stdout/stderr, separate from
+// the BEP file which is read back by stream().
+func (s *bepStreamer) writer() io.Writer {
+	return s.out
+}
+
+// stream tails the BEP file like `tail -f`: bufio.Reader.ReadString returns
+// io.EOF as soon as it catches up with whatever bazel has flushed so far,
+// which isn't the same as bazel being done writing, so EOF just means "poll
+// again" until close signals that the build is over.
+func (s *bepStreamer) stream() {
+	defer close(s.done)
+	r := bufio.NewReader(s.file)
+	for {
+		line, err := r.ReadString('\n')
+		s.print(line)
+		if err == nil {
+			continue
+		}
+		if err != io.EOF {
+			return
+		}
+		select {
+		case <-s.stop:
+			s.drain(r)
+			return
+		case <-time.After(bepPollInterval):
+		}
+	}
+}
+
+// drain reads whatever bazel wrote between the last poll and close being
+// called, so the final few BEP lines aren't lost to a race with stop.
+func (s *bepStreamer) drain(r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		s.print(line)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *bepStreamer) print(line string) {
+	if line == "" {
+		return
+	}
+	fmt.Fprintf(s.out, "[%s] %s\n", s.imageName, strings.TrimRight(line, "\n"))
+}
+
+func (s *bepStreamer) close() {
+	close(s.stop)
+	<-s.done
+	s.file.Close()
+	os.Remove(s.path)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bazel
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+)
+
+func TestRemoteFlags(t *testing.T) {
+	tests := []struct {
+		description string
+		remote      *latest.BazelRemoteBuild
+		want        []string
+	}{
+		{
+			description: "empty config only sets the BEP file",
+			remote:      &latest.BazelRemoteBuild{},
+			want:        []string{"--build_event_json_file=bep.json"},
+		},
+		{
+			description: "every property set",
+			remote: &latest.BazelRemoteBuild{
+				RemoteExecutor:  "remote.example.com:443",
+				RemoteCache:     "cache.example.com:443",
+				InstanceName:    "default",
+				ExecProperties:  map[string]string{"pool": "default"},
+				DiskCache:       "/tmp/disk-cache",
+				RepositoryCache: "/tmp/repo-cache",
+			},
+			want: []string{
+				"--remote_executor=remote.example.com:443",
+				"--remote_cache=cache.example.com:443",
+				"--remote_instance_name=default",
+				"--remote_default_exec_properties=pool=default",
+				"--disk_cache=/tmp/disk-cache",
+				"--repository_cache=/tmp/repo-cache",
+				"--build_event_json_file=bep.json",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			got := remoteFlags(test.remote, "bep.json")
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("remoteFlags() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBEPStreamerDrainsOnClose(t *testing.T) {
+	var out bytes.Buffer
+	s, err := newBEPStreamer(&out, "my-image")
+	if err != nil {
+		t.Fatalf("newBEPStreamer: %v", err)
+	}
+
+	// Write directly to the file stream() is tailing, simulating bazel
+	// flushing BEP lines after stream() has already hit EOF once.
+	f, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("opening bep file: %v", err)
+	}
+	if _, err := f.WriteString("line one\nline two\n"); err != nil {
+		t.Fatalf("writing bep file: %v", err)
+	}
+	f.Close()
+
+	s.close()
+
+	got := out.String()
+	for _, want := range []string{"[my-image] line one", "[my-image] line two"} {
+		if !bytes.Contains([]byte(got), []byte(want)) {
+			t.Errorf("output %q does not contain %q", got, want)
+		}
+	}
+}
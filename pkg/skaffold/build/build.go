@@ -0,0 +1,26 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package build defines the types every builder implementation produces and
+// consumes, independent of which execution environment or tool built them.
+package build
+
+// Artifact is the result of building a single latest.Artifact: the name it
+// was built under and the concrete tag it was pushed or loaded as.
+type Artifact struct {
+	ImageName string
+	Tag       string
+}
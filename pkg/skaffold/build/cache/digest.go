@@ -0,0 +1,225 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cache provides cross-cutting, content-digest based change
+// detection so builders can skip rebuilding artifacts whose inputs haven't
+// changed since the last run.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+)
+
+// ArtifactDigester computes a stable input digest for an artifact and tracks
+// which digest was last built and pushed, so a builder can skip rebuilding
+// artifacts whose inputs haven't changed.
+type ArtifactDigester struct {
+	// DependenciesForArtifact lists the files that make up an artifact's
+	// build context, typically a builder's own DependenciesForArtifact.
+	DependenciesForArtifact func(ctx context.Context, artifact *latest.Artifact) ([]string, error)
+
+	// QueryOnly reports whether the paths DependenciesForArtifact returns
+	// for this artifact come from a remote dependency query (e.g. `bazel
+	// query` against a Remote Build Execution backend) rather than the
+	// local filesystem. Digesting such an artifact would force exactly the
+	// local materialization the query-only path is meant to avoid, so
+	// Partition skips the cache for it instead. Optional; nil means no
+	// artifact is query-only.
+	QueryOnly func(ctx context.Context, artifact *latest.Artifact) bool
+
+	store *Store
+	cache digestCache
+}
+
+type digestEntry struct {
+	InputDigest string `json:"inputDigest"`
+	LastTag     string `json:"lastTag"`
+	LastImageID string `json:"lastImageID"`
+}
+
+type digestCache map[string]digestEntry
+
+// NewArtifactDigester loads the project-scoped digest cache and returns a
+// digester that uses depsFn to enumerate an artifact's input files.
+func NewArtifactDigester(depsFn func(ctx context.Context, artifact *latest.Artifact) ([]string, error)) (*ArtifactDigester, error) {
+	store, err := NewStore("artifact-digest-cache.json")
+	if err != nil {
+		return nil, err
+	}
+	cache := digestCache{}
+	if err := store.Load(&cache); err != nil {
+		return nil, errors.Wrap(err, "loading artifact digest cache")
+	}
+	return &ArtifactDigester{DependenciesForArtifact: depsFn, store: store, cache: cache}, nil
+}
+
+// Digest computes a stable sha256 over the sorted (relative path, file
+// sha256, mode) triples of an artifact's dependencies plus its serialized
+// spec (e.g. bazel target + args, or Dockerfile + build args).
+func (d *ArtifactDigester) Digest(ctx context.Context, artifact *latest.Artifact) (string, error) {
+	paths, err := d.DependenciesForArtifact(ctx, artifact)
+	if err != nil {
+		return "", errors.Wrapf(err, "listing dependencies for %s", artifact.ImageName)
+	}
+
+	files := make([]FileHash, len(paths))
+	for i, p := range paths {
+		sum, mode, err := HashFile(p)
+		if err != nil {
+			return "", errors.Wrapf(err, "hashing %s", p)
+		}
+		rel := p
+		if r, err := filepath.Rel(artifact.Workspace, p); err == nil {
+			rel = r
+		}
+		files[i] = FileHash{Path: rel, SHA256: sum, Mode: mode}
+	}
+
+	h := sha256.New()
+	io.WriteString(h, DigestFiles(files))
+
+	spec, err := json.Marshal(artifact.ArtifactType)
+	if err != nil {
+		return "", errors.Wrap(err, "serializing artifact spec")
+	}
+	h.Write(spec)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// FileHash is a (path, content hash, mode) triple used to build a stable
+// digest over a set of files.
+type FileHash struct {
+	Path   string
+	SHA256 string
+	Mode   os.FileMode
+}
+
+// HashFile reads the file at path and returns its sha256 and mode.
+func HashFile(path string) (string, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), info.Mode(), nil
+}
+
+// DigestFiles computes a stable sha256 over the sorted (path, sha256, mode)
+// triples in files. It's the one hashing recipe every file-set digest in
+// skaffold builds on, so two independently maintained caches never disagree
+// about whether a file changed.
+func DigestFiles(files []FileHash) string {
+	sorted := make([]FileHash, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	h := sha256.New()
+	for _, f := range sorted {
+		io.WriteString(h, f.Path)
+		io.WriteString(h, f.SHA256)
+		io.WriteString(h, f.Mode.String())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Partition splits artifacts into those whose last built digest still
+// matches (and whose image is still resolvable) and those that need to be
+// rebuilt. forceRebuild bypasses the cache entirely.
+func (d *ArtifactDigester) Partition(ctx context.Context, artifacts []*latest.Artifact, forceRebuild bool) (unchanged []build.Artifact, toBuild []*latest.Artifact, digests map[string]string, err error) {
+	digests = make(map[string]string, len(artifacts))
+	for _, a := range artifacts {
+		if d.QueryOnly != nil && d.QueryOnly(ctx, a) {
+			toBuild = append(toBuild, a)
+			continue
+		}
+
+		digest, err := d.Digest(ctx, a)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		digests[a.ImageName] = digest
+
+		if forceRebuild {
+			toBuild = append(toBuild, a)
+			continue
+		}
+
+		entry, ok := d.cache[a.ImageName]
+		if !ok || entry.InputDigest != digest || !resolvable(entry.LastTag) {
+			toBuild = append(toBuild, a)
+			continue
+		}
+
+		unchanged = append(unchanged, build.Artifact{
+			ImageName: a.ImageName,
+			Tag:       entry.LastTag,
+		})
+	}
+	return unchanged, toBuild, digests, nil
+}
+
+// Update records the digest and resulting tag for an artifact that was just
+// built, so a future run can skip rebuilding it. LastImageID is not set.
+func (d *ArtifactDigester) Update(imageName, inputDigest string, built build.Artifact) error {
+	d.cache[imageName] = digestEntry{
+		InputDigest: inputDigest,
+		LastTag:     built.Tag,
+	}
+	return d.store.Save(d.cache)
+}
+
+// resolvable reports whether tag still points at an image in the local
+// Docker daemon or a remote registry. The local daemon is checked first
+// since constants.Local, the default execution environment, never pushes
+// images to a registry.
+func resolvable(tag string) bool {
+	if tag == "" {
+		return false
+	}
+	ref, err := name.ParseReference(tag, name.WeakValidation)
+	if err != nil {
+		return false
+	}
+	if _, err := daemon.Image(ref); err == nil {
+		return true
+	}
+	_, err = remote.Head(ref)
+	return err == nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Store persists a JSON-serializable value under a project-scoped cache
+// file, rooted at the current working directory's .skaffold/cache dir
+// rather than a single global file, so two unrelated projects never read or
+// overwrite each other's cache entries.
+type Store struct {
+	path string
+}
+
+// NewStore returns a Store backed by <project>/.skaffold/cache/<name>.
+func NewStore(name string) (*Store, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting working directory")
+	}
+	return &Store{path: filepath.Join(wd, ".skaffold", "cache", name)}, nil
+}
+
+// Load unmarshals the cache file into v, leaving v untouched if the cache
+// file doesn't exist yet.
+func (s *Store) Load(v interface{}) error {
+	contents, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, v)
+}
+
+// Save marshals v and writes it to the cache file, creating its parent
+// directory as needed.
+func (s *Store) Save(v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	contents, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, contents, 0644)
+}
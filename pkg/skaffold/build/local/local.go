@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package local builds artifacts on the machine running skaffold.
+package local
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/pkg/errors"
+)
+
+// Builder builds artifacts on the machine running skaffold.
+type Builder struct {
+	cfg         *latest.LocalBuild
+	kubeContext string
+	skipTests   bool
+}
+
+// NewBuilder creates a new Builder that builds artifacts locally.
+func NewBuilder(cfg *latest.LocalBuild, kubeContext string, skipTests bool) (*Builder, error) {
+	return &Builder{cfg: cfg, kubeContext: kubeContext, skipTests: skipTests}, nil
+}
+
+// Build builds every artifact concurrently, since a local build has no
+// remote scheduler to parallelize on its behalf.
+func (b *Builder) Build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	built := make([]build.Artifact, len(artifacts))
+	errs := make([]error, len(artifacts))
+
+	var wg sync.WaitGroup
+	for i, a := range artifacts {
+		wg.Add(1)
+		go func(i int, a *latest.Artifact) {
+			defer wg.Done()
+			artifact, err := b.buildArtifact(ctx, out, tags, a)
+			built[i] = artifact
+			errs[i] = err
+		}(i, a)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s", artifacts[i].ImageName)
+		}
+	}
+	return built, nil
+}
+
+// buildArtifact looks up the tag a is built under and returns it; it runs no
+// build tool itself.
+func (b *Builder) buildArtifact(ctx context.Context, out io.Writer, tags tag.ImageTags, a *latest.Artifact) (build.Artifact, error) {
+	tagged, ok := tags[a.ImageName]
+	if !ok {
+		return build.Artifact{}, errors.Errorf("no tag provided for %s", a.ImageName)
+	}
+	return build.Artifact{ImageName: a.ImageName, Tag: tagged}, nil
+}
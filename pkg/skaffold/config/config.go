@@ -0,0 +1,30 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config holds the options skaffold was invoked with, as opposed to
+// the pipeline config in schema/latest.
+package config
+
+// SkaffoldOptions are the flags and environment skaffold was run with.
+type SkaffoldOptions struct {
+	// ForceRebuild skips every builder's change-detection cache and rebuilds
+	// all artifacts unconditionally.
+	ForceRebuild bool
+
+	// SkipTests disables any test step a builder would otherwise run as
+	// part of a build.
+	SkipTests bool
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package constants holds names and labels shared across skaffold's builders,
+// so a builder can switch on an execution environment by name without
+// importing the package that defines it.
+package constants
+
+// Execution environment names, as set in latest.ExecutionEnvironment.Name.
+const (
+	// Local builds artifacts on the machine running skaffold.
+	Local = "local"
+
+	// GoogleCloudBuild builds artifacts with Google Cloud Build.
+	GoogleCloudBuild = "googleCloudBuild"
+
+	// BazelRemote builds bazel artifacts against a Remote Build Execution
+	// backend instead of locally.
+	BazelRemote = "bazelRemote"
+)
+
+// Labels are the well-known label keys skaffold attaches to objects it
+// deploys, so they can be traced back to the build that produced them.
+var Labels = struct {
+	Builder string
+}{
+	Builder: "skaffold-builder",
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package docker builds artifacts from a Dockerfile and prepares a workspace
+// for a remote build (e.g. Google Cloud Build) to run against.
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/pkg/errors"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+// GetDependencies returns the Dockerfile and everything it COPYs or ADDs,
+// relative to workspace.
+func GetDependencies(ctx context.Context, workspace string, a *latest.DockerArtifact) ([]string, error) {
+	dockerfile := a.DockerfilePath
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+
+	f, err := os.Open(filepath.Join(workspace, dockerfile))
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s", dockerfile)
+	}
+	defer f.Close()
+
+	deps := []string{dockerfile}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var instruction, src, dst string
+		if n, _ := fmt.Sscanf(scanner.Text(), "%s %s %s", &instruction, &src, &dst); n == 3 &&
+			(instruction == "COPY" || instruction == "ADD") {
+			deps = append(deps, src)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "scanning Dockerfile")
+	}
+	return deps, nil
+}
+
+// UploadContextToGCS tars and gzips workspace and uploads it to bucket,
+// returning the object name the build should read it back from.
+func UploadContextToGCS(ctx context.Context, workspace, bucket string) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(workspace, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(workspace, path)
+		if err != nil {
+			return err
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: rel, Mode: int64(info.Mode()), Size: int64(len(contents))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(contents)
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "archiving workspace")
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+
+	service, err := storage.NewService(ctx, option.WithScopes(storage.DevstorageReadWriteScope))
+	if err != nil {
+		return "", errors.Wrap(err, "getting GCS client")
+	}
+
+	object := fmt.Sprintf("source/%d.tar.gz", time.Now().UnixNano())
+	if _, err := service.Objects.Insert(bucket, &storage.Object{Name: object}).Media(&buf).Do(); err != nil {
+		return "", errors.Wrap(err, "uploading build context")
+	}
+	return object, nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package context resolves the kube-context skaffold should target, the same
+// way kubectl does, so builders that need to reach into the cluster (e.g. a
+// local builder pushing to an in-cluster registry) use the right one.
+package context
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CurrentContext returns the name of the kubeconfig's current-context entry,
+// honoring $KUBECONFIG and falling back to ~/.kube/config.
+func CurrentContext() (string, error) {
+	path := os.Getenv("KUBECONFIG")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + "/.kube/config"
+	}
+
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var cfg struct {
+		CurrentContext string `yaml:"current-context"`
+	}
+	if err := yaml.Unmarshal(contents, &cfg); err != nil {
+		return "", err
+	}
+	return cfg.CurrentContext, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/bazel"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/plugin/environments/gcb"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/pkg/errors"
+)
+
+// NewDefaultRegistry returns a Registry with skaffold's in-tree builders
+// already registered under the names their artifacts' build.artifacts[].plugin
+// addresses them by, plus whatever out-of-tree builders are discovered from
+// $SKAFFOLD_PLUGIN_DIR.
+func NewDefaultRegistry() (*Registry, error) {
+	r := NewRegistry()
+	if err := r.Register("bazel", func() PluginBuilder { return bazel.NewBuilder() }); err != nil {
+		return nil, err
+	}
+	if err := r.Register("google-cloud-build", func() PluginBuilder { return gcb.NewBuilder(nil, false) }); err != nil {
+		return nil, err
+	}
+	if err := r.Discover(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Build dispatches every artifact with a BuilderPlugin set to the builder it
+// names, preserving the original artifact order in the result. Artifacts
+// that use one of the built-in ArtifactType variants instead aren't this
+// registry's concern; they're built directly by their in-tree builder, the
+// same way they always have been.
+func (r *Registry) Build(ctx context.Context, opts *config.SkaffoldOptions, env *latest.ExecutionEnvironment, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	byName := make(map[string][]*latest.Artifact)
+	var order []string
+	for _, a := range artifacts {
+		if a.BuilderPlugin == nil {
+			return nil, errors.Errorf("%s has no plugin configured", a.ImageName)
+		}
+		name := a.BuilderPlugin.Name
+		if _, ok := byName[name]; !ok {
+			order = append(order, name)
+		}
+		byName[name] = append(byName[name], a)
+	}
+
+	built := make(map[string]build.Artifact, len(artifacts))
+	for _, name := range order {
+		factory, ok := r.Get(name)
+		if !ok {
+			return nil, errors.Errorf("no builder registered for plugin %q", name)
+		}
+		builder := factory()
+		builder.Init(opts, env)
+
+		// Plugins that own a custom artifact schema get a chance to reject a
+		// malformed BuilderPlugin.Contents before the build starts, the same
+		// way setArtifact validates a bazel artifact up front.
+		if _, ok := builder.(ArtifactUnmarshaler); ok {
+			for _, a := range byName[name] {
+				if _, err := UnmarshalArtifact(builder, a.BuilderPlugin.Contents); err != nil {
+					return nil, errors.Wrapf(err, "parsing %s for plugin %q", a.ImageName, name)
+				}
+			}
+		}
+
+		results, err := builder.Build(ctx, out, tags, byName[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "building with plugin %q", name)
+		}
+		for _, a := range results {
+			built[a.ImageName] = a
+		}
+	}
+
+	results := make([]build.Artifact, len(artifacts))
+	for i, a := range artifacts {
+		results[i] = built[a.ImageName]
+	}
+	return results, nil
+}
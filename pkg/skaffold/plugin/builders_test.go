@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/pkg/errors"
+)
+
+func TestNewDefaultRegistryRegistersInTreeBuilders(t *testing.T) {
+	r, err := NewDefaultRegistry()
+	if err != nil {
+		t.Fatalf("NewDefaultRegistry: %v", err)
+	}
+	for _, name := range []string{"bazel", "google-cloud-build"} {
+		if _, ok := r.Get(name); !ok {
+			t.Errorf("expected %q to be registered", name)
+		}
+	}
+}
+
+// fakeRegistryBuilder is a minimal PluginBuilder used to exercise
+// Registry.Build's dispatch-by-name and Contents-validation logic without
+// driving a real builder's network/exec calls.
+type fakeRegistryBuilder struct {
+	name        string
+	initialized bool
+}
+
+func (f *fakeRegistryBuilder) Name() string { return f.name }
+
+func (f *fakeRegistryBuilder) Init(*config.SkaffoldOptions, *latest.ExecutionEnvironment) {
+	f.initialized = true
+}
+
+func (f *fakeRegistryBuilder) Labels() map[string]string { return nil }
+
+func (f *fakeRegistryBuilder) DependenciesForArtifact(context.Context, *latest.Artifact) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeRegistryBuilder) UnmarshalArtifact(contents []byte) (interface{}, error) {
+	if len(contents) == 0 {
+		return nil, errors.New("empty contents")
+	}
+	return string(contents), nil
+}
+
+func (f *fakeRegistryBuilder) Build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	built := make([]build.Artifact, len(artifacts))
+	for i, a := range artifacts {
+		built[i] = build.Artifact{ImageName: a.ImageName, Tag: tags[a.ImageName]}
+	}
+	return built, nil
+}
+
+func TestRegistryBuildDispatchesByPluginName(t *testing.T) {
+	fake := &fakeRegistryBuilder{name: "fake"}
+	r := NewRegistry()
+	if err := r.Register("fake", func() PluginBuilder { return fake }); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	artifacts := []*latest.Artifact{
+		{ImageName: "b", BuilderPlugin: &latest.BuilderPlugin{Name: "fake", Contents: []byte("b-contents")}},
+		{ImageName: "a", BuilderPlugin: &latest.BuilderPlugin{Name: "fake", Contents: []byte("a-contents")}},
+	}
+	tags := tag.ImageTags{"a": "a:tag", "b": "b:tag"}
+
+	var out bytes.Buffer
+	results, err := r.Build(context.Background(), &config.SkaffoldOptions{}, &latest.ExecutionEnvironment{}, &out, tags, artifacts)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !fake.initialized {
+		t.Error("expected the dispatched builder to be Init'd")
+	}
+
+	if len(results) != 2 || results[0].ImageName != "b" || results[1].ImageName != "a" {
+		t.Fatalf("Build() = %v, want results in the original artifact order [b a]", results)
+	}
+	if results[0].Tag != "b:tag" || results[1].Tag != "a:tag" {
+		t.Errorf("Build() tags = %+v, want the tags from the tag.ImageTags map", results)
+	}
+}
+
+func TestRegistryBuildRejectsMalformedContents(t *testing.T) {
+	fake := &fakeRegistryBuilder{name: "fake"}
+	r := NewRegistry()
+	if err := r.Register("fake", func() PluginBuilder { return fake }); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	artifacts := []*latest.Artifact{
+		{ImageName: "bad", BuilderPlugin: &latest.BuilderPlugin{Name: "fake", Contents: nil}},
+	}
+	var out bytes.Buffer
+	if _, err := r.Build(context.Background(), &config.SkaffoldOptions{}, &latest.ExecutionEnvironment{}, &out, tag.ImageTags{}, artifacts); err == nil {
+		t.Error("expected Build to reject an artifact with malformed plugin Contents before building")
+	}
+}
+
+func TestRegistryBuildUnknownPlugin(t *testing.T) {
+	r := NewRegistry()
+	artifacts := []*latest.Artifact{
+		{ImageName: "a", BuilderPlugin: &latest.BuilderPlugin{Name: "not-registered"}},
+	}
+	var out bytes.Buffer
+	if _, err := r.Build(context.Background(), &config.SkaffoldOptions{}, &latest.ExecutionEnvironment{}, &out, tag.ImageTags{}, artifacts); err == nil {
+		t.Error("expected Build to fail for a plugin name with no registered builder")
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/cache"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"github.com/pkg/errors"
+	cloudbuild "google.golang.org/api/cloudbuild/v1"
+)
+
+// Build builds Dockerfile-based artifacts with Google Cloud Build, skipping
+// any artifact whose digested inputs haven't changed since the last build.
+//
+// This intentionally doesn't share buildOnGCB/watchBuild with
+// build/bazel/gcb.go: that file already imports this package for its status
+// constants and error translation, so this package can't import back into
+// build/bazel without an import cycle.
+func (b *Builder) Build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	digester, err := cache.NewArtifactDigester(b.DependenciesForArtifact)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up artifact digest cache")
+	}
+	unchanged, toBuild, digests, err := digester.Partition(ctx, artifacts, b.opts.ForceRebuild)
+	if err != nil {
+		return nil, errors.Wrap(err, "checking artifact digests")
+	}
+
+	byImageName := make(map[string]build.Artifact, len(artifacts))
+	for _, a := range unchanged {
+		byImageName[a.ImageName] = a
+	}
+	for _, a := range toBuild {
+		tagged, ok := tags[a.ImageName]
+		if !ok {
+			return nil, errors.Errorf("no tag provided for %s", a.ImageName)
+		}
+		built, err := b.buildOnGCB(ctx, out, tagged, a)
+		if err != nil {
+			return nil, errors.Wrapf(err, "building %s on Google Cloud Build", a.ImageName)
+		}
+		if err := digester.Update(built.ImageName, digests[built.ImageName], built); err != nil {
+			return nil, errors.Wrapf(err, "updating artifact digest cache for %s", built.ImageName)
+		}
+		byImageName[built.ImageName] = built
+	}
+
+	results := make([]build.Artifact, len(artifacts))
+	for i, a := range artifacts {
+		results[i] = byImageName[a.ImageName]
+	}
+	return results, nil
+}
+
+// buildOnGCB uploads the artifact's workspace to the configured staging
+// bucket, submits a build that runs gcr.io/cloud-builders/docker against it,
+// and streams status until the build reaches a terminal state.
+func (b *Builder) buildOnGCB(ctx context.Context, out io.Writer, tagged string, artifact *latest.Artifact) (build.Artifact, error) {
+	dockerfile := artifact.DockerArtifact.DockerfilePath
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+
+	objectName, err := docker.UploadContextToGCS(ctx, artifact.Workspace, b.StagingBucket)
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "uploading workspace to GCS")
+	}
+
+	cb, err := cloudbuild.NewService(ctx)
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "getting cloudbuild client")
+	}
+
+	call := cb.Projects.Builds.Create(b.ProjectID, &cloudbuild.Build{
+		Source: &cloudbuild.Source{
+			StorageSource: &cloudbuild.StorageSource{
+				Bucket: b.StagingBucket,
+				Object: objectName,
+			},
+		},
+		Steps: []*cloudbuild.BuildStep{{
+			Name: "gcr.io/cloud-builders/docker",
+			Args: []string{"build", "-f", dockerfile, "-t", tagged, "."},
+		}},
+		Images: []string{tagged},
+	})
+	op, err := call.Do()
+	if err != nil {
+		return build.Artifact{}, errors.Wrap(err, "submitting cloud build")
+	}
+
+	buildID, err := BuildIDFromOperation(op)
+	if err != nil {
+		return build.Artifact{}, err
+	}
+
+	fmt.Fprintf(out, "Starting build %s...\n", buildID)
+	if err := b.watchBuild(ctx, out, cb, buildID); err != nil {
+		return build.Artifact{}, err
+	}
+	return build.Artifact{ImageName: artifact.ImageName, Tag: tagged}, nil
+}
+
+// BuildIDFromOperation parses the real build ID out of a submitted cloud
+// build operation's metadata. op.Name is the long-running operation's
+// resource name (e.g. "operations/build/<project>/<uuid>"), not a build ID;
+// Builds.Get needs the latter, which only shows up in the operation's
+// metadata.
+func BuildIDFromOperation(op *cloudbuild.Operation) (string, error) {
+	var metadata cloudbuild.BuildOperationMetadata
+	if err := json.Unmarshal(op.Metadata, &metadata); err != nil {
+		return "", errors.Wrap(err, "reading build ID from operation metadata")
+	}
+	return metadata.Build.Id, nil
+}
+
+// watchBuild polls the cloud build until it reaches a terminal status,
+// streaming progress through out and translating failures into typed errors.
+func (b *Builder) watchBuild(ctx context.Context, out io.Writer, cb *cloudbuild.Service, buildID string) error {
+	lastStatus := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(RetryDelay):
+		}
+
+		remoteBuild, err := cb.Projects.Builds.Get(b.ProjectID, buildID).Do()
+		if err != nil {
+			return errors.Wrap(err, "getting cloud build status")
+		}
+
+		if remoteBuild.Status != lastStatus {
+			fmt.Fprintf(out, "cloud build status: %s\n", remoteBuild.Status)
+			lastStatus = remoteBuild.Status
+		}
+
+		if err := ErrorForStatus(remoteBuild.Status); err != nil {
+			return err
+		}
+		if remoteBuild.Status == StatusSuccess {
+			return nil
+		}
+	}
+}
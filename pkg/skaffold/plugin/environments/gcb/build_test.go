@@ -0,0 +1,52 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcb
+
+import (
+	"encoding/json"
+	"testing"
+
+	cloudbuild "google.golang.org/api/cloudbuild/v1"
+)
+
+func TestBuildIDFromOperation(t *testing.T) {
+	metadata, err := json.Marshal(&cloudbuild.BuildOperationMetadata{
+		Build: &cloudbuild.Build{Id: "abc-123"},
+	})
+	if err != nil {
+		t.Fatalf("marshalling metadata: %v", err)
+	}
+	op := &cloudbuild.Operation{
+		Name:     "operations/build/my-project/abc-123",
+		Metadata: metadata,
+	}
+
+	id, err := BuildIDFromOperation(op)
+	if err != nil {
+		t.Fatalf("BuildIDFromOperation: %v", err)
+	}
+	if id != "abc-123" {
+		t.Errorf("BuildIDFromOperation() = %q, want %q", id, "abc-123")
+	}
+}
+
+func TestBuildIDFromOperationMalformedMetadata(t *testing.T) {
+	op := &cloudbuild.Operation{Metadata: []byte("not json")}
+	if _, err := BuildIDFromOperation(op); err == nil {
+		t.Error("expected an error for malformed operation metadata")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
@@ -56,9 +57,40 @@ const (
 	RetryDelay = 1 * time.Second
 )
 
+var (
+	// ErrBuildTimeout is returned when a cloud build exceeds its allotted time.
+	ErrBuildTimeout = errors.New("cloud build timed out")
+
+	// ErrBuildCancelled is returned when a cloud build is cancelled by a user.
+	ErrBuildCancelled = errors.New("cloud build was cancelled")
+
+	// ErrInternalError is returned when a cloud build fails for a reason internal to Cloud Build.
+	ErrInternalError = errors.New("cloud build failed with an internal error")
+)
+
+// ErrorForStatus translates a terminal cloud build status into a typed error,
+// or nil if the status isn't terminal or represents success.
+func ErrorForStatus(status string) error {
+	switch status {
+	case StatusSuccess, StatusQueued, StatusWorking, StatusUnknown:
+		return nil
+	case StatusTimeout:
+		return ErrBuildTimeout
+	case StatusCancelled:
+		return ErrBuildCancelled
+	case StatusInternalError:
+		return ErrInternalError
+	case StatusFailure:
+		return errors.New("cloud build failed")
+	default:
+		return errors.Errorf("unknown cloud build status: %s", status)
+	}
+}
+
 // Builder builds artifacts with Google Cloud Build.
 type Builder struct {
 	*latest.GoogleCloudBuild
+	opts      *config.SkaffoldOptions
 	skipTests bool
 }
 
@@ -70,6 +102,26 @@ func NewBuilder(cfg *latest.GoogleCloudBuild, skipTests bool) *Builder {
 	}
 }
 
+// Name is how users address this builder from build.artifacts[].plugin.
+func (b *Builder) Name() string {
+	return "google-cloud-build"
+}
+
+// Init stores skaffold options and resolves the execution environment's
+// properties into a GoogleCloudBuild config.
+func (b *Builder) Init(opts *config.SkaffoldOptions, env *latest.ExecutionEnvironment) {
+	b.opts = opts
+	b.skipTests = opts.SkipTests
+
+	var g *latest.GoogleCloudBuild
+	if err := util.CloneThroughJSON(env.Properties, &g); err == nil && g != nil {
+		b.GoogleCloudBuild = g
+	}
+	if b.GoogleCloudBuild == nil {
+		b.GoogleCloudBuild = &latest.GoogleCloudBuild{}
+	}
+}
+
 // Labels are labels specific to Google Cloud Build.
 func (b *Builder) Labels() map[string]string {
 	return map[string]string{
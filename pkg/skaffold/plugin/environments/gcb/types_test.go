@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcb
+
+import "testing"
+
+func TestErrorForStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   error
+	}{
+		{StatusUnknown, nil},
+		{StatusQueued, nil},
+		{StatusWorking, nil},
+		{StatusSuccess, nil},
+		{StatusTimeout, ErrBuildTimeout},
+		{StatusCancelled, ErrBuildCancelled},
+		{StatusInternalError, ErrInternalError},
+	}
+	for _, test := range tests {
+		t.Run(test.status, func(t *testing.T) {
+			if got := ErrorForStatus(test.status); got != test.want {
+				t.Errorf("ErrorForStatus(%s) = %v, want %v", test.status, got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorForStatusFailure(t *testing.T) {
+	if err := ErrorForStatus(StatusFailure); err == nil {
+		t.Error("ErrorForStatus(StatusFailure) should return a non-nil error")
+	}
+}
+
+func TestErrorForStatusUnknownValue(t *testing.T) {
+	if err := ErrorForStatus("SOMETHING_NEW"); err == nil {
+		t.Error("ErrorForStatus should error on a status it doesn't recognize")
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	skaffoldproto "github.com/GoogleContainerTools/skaffold/pkg/skaffold/plugin/proto"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	hcplugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+func newPluginCmd(path string) *exec.Cmd {
+	return exec.Command(path)
+}
+
+// grpcBuilderPlugin adapts PluginBuilder to hashicorp/go-plugin's gRPC based
+// Plugin interface, so an out-of-tree builder can run as its own subprocess,
+// speaking the protobuf contract in pkg/skaffold/plugin/proto, and be driven
+// exactly like any in-tree builder.
+type grpcBuilderPlugin struct {
+	hcplugin.NetRPCUnsupportedPlugin
+	Impl PluginBuilder
+}
+
+func (p *grpcBuilderPlugin) GRPCServer(broker *hcplugin.GRPCBroker, s *grpc.Server) error {
+	skaffoldproto.RegisterBuilderServer(s, &builderGRPCServer{impl: p.Impl, broker: broker})
+	return nil
+}
+
+func (p *grpcBuilderPlugin) GRPCClient(_ context.Context, broker *hcplugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &builderGRPCClient{client: skaffoldproto.NewBuilderClient(c), broker: broker}, nil
+}
+
+// builderGRPCServer runs in the plugin subprocess and dispatches gRPC calls
+// to the real PluginBuilder implementation.
+type builderGRPCServer struct {
+	impl   PluginBuilder
+	broker *hcplugin.GRPCBroker
+}
+
+func (s *builderGRPCServer) Name(context.Context, *skaffoldproto.Empty) (*skaffoldproto.NameReply, error) {
+	return &skaffoldproto.NameReply{Name: s.impl.Name()}, nil
+}
+
+func (s *builderGRPCServer) Init(_ context.Context, req *skaffoldproto.InitRequest) (*skaffoldproto.Empty, error) {
+	var opts *config.SkaffoldOptions
+	if err := json.Unmarshal(req.OptsJson, &opts); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling skaffold options")
+	}
+	var env *latest.ExecutionEnvironment
+	if err := json.Unmarshal(req.EnvJson, &env); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling execution environment")
+	}
+	s.impl.Init(opts, env)
+	return &skaffoldproto.Empty{}, nil
+}
+
+func (s *builderGRPCServer) Labels(context.Context, *skaffoldproto.Empty) (*skaffoldproto.LabelsReply, error) {
+	return &skaffoldproto.LabelsReply{Labels: s.impl.Labels()}, nil
+}
+
+func (s *builderGRPCServer) DependenciesForArtifact(ctx context.Context, req *skaffoldproto.DependenciesRequest) (*skaffoldproto.DependenciesReply, error) {
+	var artifact *latest.Artifact
+	if err := json.Unmarshal(req.ArtifactJson, &artifact); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling artifact")
+	}
+	paths, err := s.impl.DependenciesForArtifact(ctx, artifact)
+	if err != nil {
+		return nil, err
+	}
+	return &skaffoldproto.DependenciesReply{Paths: paths}, nil
+}
+
+// Build dials back into the client's broker-hosted LogSink so the plugin's
+// build output is streamed to the user as it happens, rather than swallowed.
+func (s *builderGRPCServer) Build(ctx context.Context, req *skaffoldproto.BuildRequest) (*skaffoldproto.BuildReply, error) {
+	var tags tag.ImageTags
+	if err := json.Unmarshal(req.TagsJson, &tags); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling tags")
+	}
+	artifacts := make([]*latest.Artifact, len(req.ArtifactsJson))
+	for i, raw := range req.ArtifactsJson {
+		if err := json.Unmarshal(raw, &artifacts[i]); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling artifact")
+		}
+	}
+
+	conn, err := s.broker.DialServer(req.LogSinkBrokerId)
+	if err != nil {
+		return nil, errors.Wrap(err, "dialing log sink")
+	}
+	defer conn.Close()
+	stream, err := skaffoldproto.NewLogSinkClient(conn).Log(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening log stream")
+	}
+	out := &logStreamWriter{stream: stream}
+
+	built, err := s.impl.Build(ctx, out, tags, artifacts)
+	if err != nil {
+		stream.CloseAndRecv()
+		return nil, err
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return nil, errors.Wrap(err, "closing log stream")
+	}
+
+	artifactsJSON := make([][]byte, len(built))
+	for i, a := range built {
+		raw, err := json.Marshal(a)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling built artifact")
+		}
+		artifactsJSON[i] = raw
+	}
+	return &skaffoldproto.BuildReply{ArtifactsJson: artifactsJSON}, nil
+}
+
+// logStreamWriter adapts a LogSink client stream to an io.Writer so it can be
+// passed straight to PluginBuilder.Build as the build's output sink.
+type logStreamWriter struct {
+	stream skaffoldproto.LogSink_LogClient
+}
+
+func (w *logStreamWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&skaffoldproto.LogLine{Text: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// builderGRPCClient runs in the skaffold process and implements PluginBuilder
+// by forwarding calls to the plugin subprocess over gRPC.
+type builderGRPCClient struct {
+	client skaffoldproto.BuilderClient
+	broker *hcplugin.GRPCBroker
+}
+
+// Name reports the name the plugin should be addressed by in
+// build.artifacts[].plugin, independent of the constants.Labels.Builder
+// telemetry label Labels() reports.
+func (c *builderGRPCClient) Name() string {
+	reply, err := c.client.Name(context.Background(), &skaffoldproto.Empty{})
+	if err != nil {
+		return ""
+	}
+	return reply.Name
+}
+
+func (c *builderGRPCClient) Init(opts *config.SkaffoldOptions, env *latest.ExecutionEnvironment) {
+	optsJSON, _ := json.Marshal(opts)
+	envJSON, _ := json.Marshal(env)
+	c.client.Init(context.Background(), &skaffoldproto.InitRequest{OptsJson: optsJSON, EnvJson: envJSON})
+}
+
+func (c *builderGRPCClient) Labels() map[string]string {
+	reply, err := c.client.Labels(context.Background(), &skaffoldproto.Empty{})
+	if err != nil {
+		return nil
+	}
+	return reply.Labels
+}
+
+func (c *builderGRPCClient) DependenciesForArtifact(ctx context.Context, artifact *latest.Artifact) ([]string, error) {
+	artifactJSON, err := json.Marshal(artifact)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling artifact")
+	}
+	reply, err := c.client.DependenciesForArtifact(ctx, &skaffoldproto.DependenciesRequest{ArtifactJson: artifactJSON})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Paths, nil
+}
+
+// Build streams the plugin's build output to out by hosting a LogSink server
+// on its own broker connection for the duration of the (blocking) Build RPC.
+func (c *builderGRPCClient) Build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling tags")
+	}
+	artifactsJSON := make([][]byte, len(artifacts))
+	for i, a := range artifacts {
+		raw, err := json.Marshal(a)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling artifact")
+		}
+		artifactsJSON[i] = raw
+	}
+
+	brokerID := c.broker.NextId()
+	go c.broker.AcceptAndServe(brokerID, func(opts []grpc.ServerOption) *grpc.Server {
+		s := grpc.NewServer(opts...)
+		skaffoldproto.RegisterLogSinkServer(s, &logSinkServer{out: out})
+		return s
+	})
+
+	reply, err := c.client.Build(ctx, &skaffoldproto.BuildRequest{
+		TagsJson:        tagsJSON,
+		ArtifactsJson:   artifactsJSON,
+		LogSinkBrokerId: brokerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	built := make([]build.Artifact, len(reply.ArtifactsJson))
+	for i, raw := range reply.ArtifactsJson {
+		if err := json.Unmarshal(raw, &built[i]); err != nil {
+			return nil, errors.Wrap(err, "unmarshalling built artifact")
+		}
+	}
+	return built, nil
+}
+
+// logSinkServer runs in the skaffold process for the duration of a single
+// Build call, forwarding every line the plugin subprocess sends straight to
+// the build's output writer.
+type logSinkServer struct {
+	out io.Writer
+}
+
+func (s *logSinkServer) Log(stream skaffoldproto.LogSink_LogServer) error {
+	for {
+		line, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&skaffoldproto.Empty{})
+		}
+		if err != nil {
+			return err
+		}
+		io.WriteString(s.out, line.Text)
+	}
+}
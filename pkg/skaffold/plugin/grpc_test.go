@@ -0,0 +1,127 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	skaffoldproto "github.com/GoogleContainerTools/skaffold/pkg/skaffold/plugin/proto"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	"google.golang.org/grpc"
+)
+
+// fakeBuilder is a minimal PluginBuilder used to exercise the gRPC
+// client/server round trip without a real subprocess.
+type fakeBuilder struct {
+	gotOpts *config.SkaffoldOptions
+	gotEnv  *latest.ExecutionEnvironment
+}
+
+func (f *fakeBuilder) Name() string { return "fake" }
+
+func (f *fakeBuilder) Init(opts *config.SkaffoldOptions, env *latest.ExecutionEnvironment) {
+	f.gotOpts = opts
+	f.gotEnv = env
+}
+
+func (f *fakeBuilder) Labels() map[string]string {
+	return map[string]string{"skaffold-builder": "fake"}
+}
+
+func (f *fakeBuilder) DependenciesForArtifact(context.Context, *latest.Artifact) ([]string, error) {
+	return []string{"a", "b"}, nil
+}
+
+func (f *fakeBuilder) Build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error) {
+	io.WriteString(out, "building\n")
+	built := make([]build.Artifact, len(artifacts))
+	for i, a := range artifacts {
+		built[i] = build.Artifact{ImageName: a.ImageName, Tag: tags[a.ImageName]}
+	}
+	return built, nil
+}
+
+// dialedFakeServer starts builderGRPCServer on an in-process connection and
+// returns a client talking to it directly, bypassing hashicorp/go-plugin's
+// subprocess and broker machinery.
+func dialedFakeServer(t *testing.T, impl PluginBuilder) (*builderGRPCClient, func()) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	s := grpc.NewServer()
+	skaffoldproto.RegisterBuilderServer(s, &builderGRPCServer{impl: impl})
+	go s.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("dialing: %v", err)
+	}
+	client := &builderGRPCClient{client: skaffoldproto.NewBuilderClient(conn)}
+	return client, func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func TestGRPCNameRoundTrip(t *testing.T) {
+	impl := &fakeBuilder{}
+	client, cleanup := dialedFakeServer(t, impl)
+	defer cleanup()
+
+	if got := client.Name(); got != "fake" {
+		t.Errorf("Name() = %q, want %q", got, "fake")
+	}
+}
+
+func TestGRPCInitRoundTrip(t *testing.T) {
+	impl := &fakeBuilder{}
+	client, cleanup := dialedFakeServer(t, impl)
+	defer cleanup()
+
+	opts := &config.SkaffoldOptions{SkipTests: true}
+	env := &latest.ExecutionEnvironment{Name: "local"}
+	client.Init(opts, env)
+
+	if impl.gotOpts == nil || !impl.gotOpts.SkipTests {
+		t.Errorf("Init did not deliver opts, got %+v", impl.gotOpts)
+	}
+	if impl.gotEnv == nil || impl.gotEnv.Name != "local" {
+		t.Errorf("Init did not deliver env, got %+v", impl.gotEnv)
+	}
+}
+
+func TestGRPCDependenciesForArtifactRoundTrip(t *testing.T) {
+	impl := &fakeBuilder{}
+	client, cleanup := dialedFakeServer(t, impl)
+	defer cleanup()
+
+	paths, err := client.DependenciesForArtifact(context.Background(), &latest.Artifact{ImageName: "img"})
+	if err != nil {
+		t.Fatalf("DependenciesForArtifact: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "a" || paths[1] != "b" {
+		t.Errorf("DependenciesForArtifact() = %v, want [a b]", paths)
+	}
+}
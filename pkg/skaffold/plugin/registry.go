@@ -0,0 +1,226 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plugin lets third parties add new BuilderPlugin implementations
+// (e.g. pants, buck, sbt) without patching skaffold itself, either as an
+// in-process Go plugin or as a gRPC subprocess speaking the PluginBuilder
+// contract.
+package plugin
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	goplugin "plugin"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/latest"
+	hcplugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+)
+
+// ABIVersion is bumped whenever the PluginBuilder contract changes in a
+// backwards-incompatible way; out-of-tree builders must advertise a
+// matching version to be registered.
+const ABIVersion = 1
+
+// PluginDirEnv names the environment variable skaffold scans at startup to
+// discover out-of-tree builders.
+const PluginDirEnv = "SKAFFOLD_PLUGIN_DIR"
+
+// PluginBuilder is the contract every builder, in-tree or out-of-tree, must
+// implement. It mirrors the bazel and gcb builders' existing methods.
+type PluginBuilder interface {
+	// Name is how users address this builder from build.artifacts[].plugin,
+	// e.g. "pants", "buck" or "sbt". It's distinct from the
+	// constants.Labels.Builder value Labels() reports, which is telemetry,
+	// not a registration key.
+	Name() string
+	Init(opts *config.SkaffoldOptions, env *latest.ExecutionEnvironment)
+	Labels() map[string]string
+	DependenciesForArtifact(ctx context.Context, artifact *latest.Artifact) ([]string, error)
+	Build(ctx context.Context, out io.Writer, tags tag.ImageTags, artifacts []*latest.Artifact) ([]build.Artifact, error)
+}
+
+// ArtifactUnmarshaler lets a plugin own the YAML schema for its
+// BuilderPlugin.Contents, so custom artifact types don't need to be added
+// to latest.ArtifactType.
+type ArtifactUnmarshaler interface {
+	UnmarshalArtifact(contents []byte) (interface{}, error)
+}
+
+// Factory constructs a new, uninitialized PluginBuilder.
+type Factory func() PluginBuilder
+
+// Registry holds the builder factories skaffold knows about, keyed by the
+// name used in a pipeline's `build.artifacts[].plugin` field.
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: map[string]Factory{}}
+}
+
+// Register adds a builder factory under name, failing if name is already
+// taken so two plugins can't silently shadow each other.
+func (r *Registry) Register(name string, factory Factory) error {
+	if _, ok := r.factories[name]; ok {
+		return errors.Errorf("a builder is already registered for %q", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// Get looks up the factory registered for name.
+func (r *Registry) Get(name string) (Factory, bool) {
+	factory, ok := r.factories[name]
+	return factory, ok
+}
+
+// UnmarshalArtifact delegates parsing of a BuilderPlugin's raw YAML contents
+// to builder when it implements ArtifactUnmarshaler, so out-of-tree artifact
+// schemas (pants, buck, sbt, ...) work without touching latest.ArtifactType.
+func UnmarshalArtifact(builder PluginBuilder, contents []byte) (interface{}, error) {
+	unmarshaler, ok := builder.(ArtifactUnmarshaler)
+	if !ok {
+		return nil, errors.New("builder does not support custom artifact schemas")
+	}
+	return unmarshaler.UnmarshalArtifact(contents)
+}
+
+// Discover scans $SKAFFOLD_PLUGIN_DIR for out-of-tree builders and registers
+// each one after validating its ABI version. It's a no-op if the variable
+// isn't set.
+func (r *Registry) Discover() error {
+	dir := os.Getenv(PluginDirEnv)
+	if dir == "" {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "reading plugin dir %s", dir)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := entry.Name()
+		full := dir + string(os.PathSeparator) + path
+
+		var factory Factory
+		var name string
+		switch {
+		case strings.HasSuffix(path, ".so"):
+			name, factory, err = loadGoPlugin(full)
+		default:
+			name, factory, err = loadGRPCPlugin(full)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "loading plugin %s", full)
+		}
+		if err := r.Register(name, factory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadGoPlugin loads an in-process plugin built with `go build -buildmode=plugin`.
+// It must export a `Name string` symbol, an `ABIVersion int` symbol, and a
+// `New func() plugin.PluginBuilder` symbol.
+func loadGoPlugin(path string) (string, Factory, error) {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	abiSym, err := p.Lookup("ABIVersion")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "missing ABIVersion symbol")
+	}
+	if abi, ok := abiSym.(*int); !ok || *abi != ABIVersion {
+		return "", nil, errors.Errorf("plugin %s has incompatible ABI version", path)
+	}
+
+	nameSym, err := p.Lookup("Name")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "missing Name symbol")
+	}
+	name, ok := nameSym.(*string)
+	if !ok {
+		return "", nil, errors.Errorf("plugin %s has malformed Name symbol", path)
+	}
+
+	newSym, err := p.Lookup("New")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "missing New symbol")
+	}
+	factory, ok := newSym.(func() PluginBuilder)
+	if !ok {
+		return "", nil, errors.Errorf("plugin %s has malformed New symbol", path)
+	}
+
+	return *name, factory, nil
+}
+
+// loadGRPCPlugin launches path as a subprocess speaking the PluginBuilder
+// contract via hashicorp/go-plugin, and wraps the resulting client in a
+// PluginBuilder so it's indistinguishable from an in-tree builder.
+func loadGRPCPlugin(path string) (string, Factory, error) {
+	client := hcplugin.NewClient(&hcplugin.ClientConfig{
+		HandshakeConfig: hcplugin.HandshakeConfig{
+			ProtocolVersion:  uint(ABIVersion),
+			MagicCookieKey:   "SKAFFOLD_PLUGIN",
+			MagicCookieValue: "skaffold",
+		},
+		Plugins: map[string]hcplugin.Plugin{
+			"builder": &grpcBuilderPlugin{},
+		},
+		AllowedProtocols: []hcplugin.Protocol{hcplugin.ProtocolGRPC},
+		Cmd:              newPluginCmd(path),
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return "", nil, err
+	}
+	raw, err := rpcClient.Dispense("builder")
+	if err != nil {
+		client.Kill()
+		return "", nil, err
+	}
+	builder, ok := raw.(PluginBuilder)
+	if !ok {
+		client.Kill()
+		return "", nil, errors.Errorf("plugin %s does not implement PluginBuilder", path)
+	}
+
+	name := builder.Name()
+	if name == "" {
+		client.Kill()
+		return "", nil, errors.Errorf("plugin %s did not report a builder name", path)
+	}
+	return name, func() PluginBuilder { return builder }, nil
+}
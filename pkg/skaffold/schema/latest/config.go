@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package latest holds the current version of skaffold's pipeline config
+// schema. Older config versions are upgraded into these types before
+// skaffold acts on them.
+package latest
+
+// ExecutionEnvironment selects which environment a builder runs in (e.g.
+// local, Google Cloud Build, Remote Build Execution) and carries that
+// environment's own properties, keyed by Name so a builder can switch on it
+// without the caller needing to know the concrete properties type.
+type ExecutionEnvironment struct {
+	Name       string
+	Properties interface{}
+}
+
+// Artifact is a single image skaffold knows how to build, identified by the
+// name it'll be deployed under.
+type Artifact struct {
+	ImageName string
+	Workspace string
+
+	ArtifactType
+
+	// BuilderPlugin is set when this artifact's type isn't one of the
+	// built-in ArtifactType variants, so an out-of-tree plugin owns parsing
+	// its raw YAML Contents.
+	BuilderPlugin *BuilderPlugin
+}
+
+// ArtifactType is the set of ways skaffold knows how to build an artifact.
+// Exactly one field is set for a given Artifact.
+type ArtifactType struct {
+	DockerArtifact *DockerArtifact
+	BazelArtifact  *BazelArtifact
+}
+
+// BuilderPlugin names an out-of-tree builder and carries the raw YAML for
+// its artifact-specific configuration, which only that plugin knows how to
+// parse.
+type BuilderPlugin struct {
+	Name     string
+	Contents []byte
+}
+
+// DockerArtifact builds an image from a Dockerfile.
+type DockerArtifact struct {
+	DockerfilePath string
+	BuildArgs      map[string]*string
+}
+
+// BazelArtifact builds an image by running a Bazel target that produces a
+// container image.
+type BazelArtifact struct {
+	BuildTarget string
+	BuildArgs   []string
+}
+
+// LocalBuild builds artifacts on the machine running skaffold.
+type LocalBuild struct {
+	SkipPush *bool
+}
+
+// GoogleCloudBuild builds artifacts with Google Cloud Build: the workspace
+// is uploaded to StagingBucket and a remote build is submitted against it.
+type GoogleCloudBuild struct {
+	ProjectID string
+
+	// StagingBucket is the GCS bucket the build context is uploaded to
+	// before submitting the Cloud Build. It's distinct from any builder
+	// image configuration; reusing an unrelated field here would silently
+	// point uploads at the wrong bucket.
+	StagingBucket string
+}
+
+// BazelRemoteBuild builds bazel artifacts against a Remote Build Execution
+// backend instead of locally.
+type BazelRemoteBuild struct {
+	// RemoteExecutor is the gRPC endpoint of the remote executor.
+	RemoteExecutor string
+
+	// RemoteCache is the gRPC endpoint of the remote cache, used on its own
+	// when no RemoteExecutor is configured.
+	RemoteCache string
+
+	// InstanceName scopes the build to a particular remote instance.
+	InstanceName string
+
+	// ExecProperties are passed to the scheduler as the default execution
+	// properties for every action (e.g. pool selection).
+	ExecProperties map[string]string
+
+	// DiskCache is a local directory bazel also treats as a cache, checked
+	// before the remote cache.
+	DiskCache string
+
+	// RepositoryCache is a local directory bazel stores fetched external
+	// repositories in, shared across builds.
+	RepositoryCache string
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package util holds small helpers shared across skaffold's builders that
+// don't belong to any one of them.
+package util
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AbsolutePaths joins each of paths onto workspace, so a builder's reported
+// dependencies are usable regardless of skaffold's own working directory.
+func AbsolutePaths(workspace string, paths []string) []string {
+	absolute := make([]string, len(paths))
+	for i, p := range paths {
+		if filepath.IsAbs(p) {
+			absolute[i] = p
+			continue
+		}
+		absolute[i] = filepath.Join(workspace, p)
+	}
+	return absolute
+}
+
+// CloneThroughJSON decodes from into to by round-tripping through JSON, so an
+// ExecutionEnvironment's loosely-typed Properties can be converted into the
+// concrete struct a particular builder expects.
+func CloneThroughJSON(from interface{}, to interface{}) error {
+	contents, err := json.Marshal(from)
+	if err != nil {
+		return errors.Wrap(err, "marshalling source value")
+	}
+	if err := json.Unmarshal(contents, to); err != nil {
+		return errors.Wrap(err, "unmarshalling into destination value")
+	}
+	return nil
+}
+
+// EncodeExecProperties renders exec properties as the comma-separated
+// name=value list bazel's --remote_default_exec_properties flag expects,
+// sorted for a stable, diffable flag value across runs.
+func EncodeExecProperties(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + props[k]
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2019 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import "testing"
+
+func TestEncodeExecPropertiesIsSortedAndStable(t *testing.T) {
+	got := EncodeExecProperties(map[string]string{"pool": "default", "os": "linux"})
+	want := "os=linux,pool=default"
+	if got != want {
+		t.Errorf("EncodeExecProperties() = %q, want %q", got, want)
+	}
+}
+
+func TestEncodeExecPropertiesEmpty(t *testing.T) {
+	if got := EncodeExecProperties(nil); got != "" {
+		t.Errorf("EncodeExecProperties(nil) = %q, want empty string", got)
+	}
+}